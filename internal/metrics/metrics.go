@@ -0,0 +1,65 @@
+// Package metrics exposes the Prometheus collectors llm-mux instruments
+// itself with: how often quota groups block requests and for how long, and
+// how effective the token caches are.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// QuotaGroupBlocked reports whether a quota group is currently blocked
+	// (1) or open (0), labeled by the provider, group and the model that
+	// triggered the block.
+	QuotaGroupBlocked = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "llmmux_quota_group_blocked",
+		Help: "Whether a quota group is currently blocked (1) or open (0).",
+	}, []string{"provider", "group", "source_model"})
+
+	// QuotaGroupBlockDuration observes how long a quota group stayed
+	// blocked, recorded when it is cleared.
+	QuotaGroupBlockDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "llmmux_quota_group_block_duration_seconds",
+		Help:    "How long a quota group stayed blocked before being cleared.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 14), // 1s .. 8192s (~2.3h)
+	}, []string{"provider", "group"})
+
+	// QuotaGroupHits counts every quota-group check, labeled by whether the
+	// request was allowed or blocked.
+	QuotaGroupHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "llmmux_quota_group_hits_total",
+		Help: "Total quota-group checks, labeled by outcome.",
+	}, []string{"provider", "group", "outcome"})
+
+	// TokenCacheLookups counts TokenCache.Get calls, labeled by cache name
+	// and whether they hit or missed.
+	TokenCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "llmmux_token_cache_lookups_total",
+		Help: "Total TokenCache lookups, labeled by cache name and result.",
+	}, []string{"name", "result"})
+
+	// TokenCacheEvictions counts entries a TokenCache has evicted to stay
+	// within capacity, labeled by cache name.
+	TokenCacheEvictions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "llmmux_token_cache_evictions_total",
+		Help: "Total entries evicted from a TokenCache, labeled by cache name.",
+	}, []string{"name"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		QuotaGroupBlocked,
+		QuotaGroupBlockDuration,
+		QuotaGroupHits,
+		TokenCacheLookups,
+		TokenCacheEvictions,
+	)
+}
+
+// Handler returns the http.Handler to mount at /metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -1,8 +1,11 @@
 package util
 
 import (
+	"container/list"
 	"hash/fnv"
 	"sync"
+
+	"github.com/LongLenart/llm-mux/internal/metrics"
 )
 
 const (
@@ -10,31 +13,86 @@ const (
 	maxEntriesPerShard = 64
 )
 
+// tokenCacheEntry is the value stored in a shard's LRU list.Element.
 type tokenCacheEntry struct {
 	hash   uint64
 	tokens int
+	// size is len(content) at insertion time, used to enforce maxBytes.
+	size int
 }
 
+// tokenCacheShard is a proper LRU: index gives O(1) lookup, order tracks
+// recency with the most recently used entry at the front, so Get promotes
+// to front and Set evicts from the back, both O(1).
 type tokenCacheShard struct {
-	mu      sync.RWMutex
-	entries []tokenCacheEntry
+	mu        sync.Mutex
+	capacity  int
+	maxBytes  int64
+	usedBytes int64
+	index     map[uint64]*list.Element
+	order     *list.List
 }
 
+// TokenCache is a sharded, size-aware LRU cache from content hash to token
+// count. Sharding by fnv64a hash spreads lock contention across numShards
+// independent LRUs.
 type TokenCache struct {
+	name   string
 	shards [numShards]*tokenCacheShard
 }
 
+// TokenCacheOption configures a TokenCache at construction time.
+type TokenCacheOption func(*tokenCacheConfig)
+
+type tokenCacheConfig struct {
+	capacityPerShard int
+	maxBytesPerShard int64
+}
+
+// WithCapacity sets the maximum number of entries kept per shard. Tool
+// schemas, system instructions and message content have very different
+// working-set sizes, so each TokenCache can size this independently;
+// defaults to maxEntriesPerShard.
+func WithCapacity(entriesPerShard int) TokenCacheOption {
+	return func(c *tokenCacheConfig) { c.capacityPerShard = entriesPerShard }
+}
+
+// WithMaxBytes caps the total length of cached content strings per shard,
+// so a handful of enormous prompts can't monopolize the cache regardless of
+// entry count. Zero (the default) means no byte budget.
+func WithMaxBytes(maxBytesPerShard int64) TokenCacheOption {
+	return func(c *tokenCacheConfig) { c.maxBytesPerShard = maxBytesPerShard }
+}
+
 var (
-	ToolTokenCache        = NewTokenCache()
-	InstructionTokenCache = NewTokenCache()
-	ContentTokenCache     = NewTokenCache()
+	ToolTokenCache        = NewNamedTokenCache("tool")
+	InstructionTokenCache = NewNamedTokenCache("instruction")
+	ContentTokenCache     = NewNamedTokenCache("content", WithCapacity(1024), WithMaxBytes(64<<20))
 )
 
+// NewTokenCache creates an unnamed TokenCache with the default capacity.
+// Prefer NewNamedTokenCache so its hit/miss and eviction metrics are
+// distinguishable from other caches.
 func NewTokenCache() *TokenCache {
-	tc := &TokenCache{}
+	return NewNamedTokenCache("")
+}
+
+// NewNamedTokenCache creates a TokenCache whose Prometheus metrics are
+// labeled with name, so e.g. the tool-schema, instruction and content caches
+// show up as distinct series despite sharing the same implementation.
+func NewNamedTokenCache(name string, opts ...TokenCacheOption) *TokenCache {
+	cfg := tokenCacheConfig{capacityPerShard: maxEntriesPerShard}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	tc := &TokenCache{name: name}
 	for i := range tc.shards {
 		tc.shards[i] = &tokenCacheShard{
-			entries: make([]tokenCacheEntry, 0, maxEntriesPerShard),
+			capacity: cfg.capacityPerShard,
+			maxBytes: cfg.maxBytesPerShard,
+			index:    make(map[uint64]*list.Element, cfg.capacityPerShard),
+			order:    list.New(),
 		}
 	}
 	return tc
@@ -46,40 +104,74 @@ func hashContent(s string) uint64 {
 	return h.Sum64()
 }
 
+// Get looks up content's cached token count, promoting it to
+// most-recently-used on a hit.
 func (tc *TokenCache) Get(content string) (int, bool) {
 	hash := hashContent(content)
 	shard := tc.shards[hash%numShards]
 
-	shard.mu.RLock()
-	defer shard.mu.RUnlock()
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
-	for _, e := range shard.entries {
-		if e.hash == hash {
-			return e.tokens, true
-		}
+	if el, ok := shard.index[hash]; ok {
+		shard.order.MoveToFront(el)
+		metrics.TokenCacheLookups.WithLabelValues(tc.name, "hit").Inc()
+		return el.Value.(*tokenCacheEntry).tokens, true
 	}
+	metrics.TokenCacheLookups.WithLabelValues(tc.name, "miss").Inc()
 	return 0, false
 }
 
+// Set records content's token count, evicting least-recently-used entries
+// from the back of the shard's list until it fits within the configured
+// entry count and byte budget.
 func (tc *TokenCache) Set(content string, tokens int) {
 	hash := hashContent(content)
 	shard := tc.shards[hash%numShards]
+	size := len(content)
 
 	shard.mu.Lock()
 	defer shard.mu.Unlock()
 
-	// Check if already exists
-	for i, e := range shard.entries {
-		if e.hash == hash {
-			shard.entries[i].tokens = tokens
+	if el, ok := shard.index[hash]; ok {
+		entry := el.Value.(*tokenCacheEntry)
+		shard.usedBytes += int64(size - entry.size)
+		entry.tokens = tokens
+		entry.size = size
+		shard.order.MoveToFront(el)
+		tc.evictShard(shard)
+		return
+	}
+
+	entry := &tokenCacheEntry{hash: hash, tokens: tokens, size: size}
+	shard.index[hash] = shard.order.PushFront(entry)
+	shard.usedBytes += int64(size)
+	tc.evictShard(shard)
+}
+
+// evictShard drops entries from the back of shard.order (least recently
+// used) until it satisfies both the entry-count capacity and the byte
+// budget. Must be called with shard.mu held.
+func (tc *TokenCache) evictShard(shard *tokenCacheShard) {
+	for tc.shardOverBudget(shard) {
+		back := shard.order.Back()
+		if back == nil {
 			return
 		}
+		entry := back.Value.(*tokenCacheEntry)
+		shard.order.Remove(back)
+		delete(shard.index, entry.hash)
+		shard.usedBytes -= int64(entry.size)
+		metrics.TokenCacheEvictions.WithLabelValues(tc.name).Inc()
 	}
+}
 
-	// Evict oldest if full
-	if len(shard.entries) >= maxEntriesPerShard {
-		shard.entries = shard.entries[1:]
+func (tc *TokenCache) shardOverBudget(shard *tokenCacheShard) bool {
+	if shard.capacity > 0 && shard.order.Len() > shard.capacity {
+		return true
 	}
-
-	shard.entries = append(shard.entries, tokenCacheEntry{hash: hash, tokens: tokens})
+	if shard.maxBytes > 0 && shard.usedBytes > shard.maxBytes {
+		return true
+	}
+	return false
 }
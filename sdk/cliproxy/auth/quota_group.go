@@ -1,11 +1,20 @@
 package auth
 
 import (
+	"context"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/LongLenart/llm-mux/internal/metrics"
 )
 
+// quotaGroupStoreTimeout bounds every individual read/write against a
+// registered QuotaGroupStore so a slow or unreachable backend can never
+// stall the request hot path; on timeout callers fall back to the local
+// in-memory view.
+const quotaGroupStoreTimeout = 2 * time.Second
+
 // QuotaGroupResolver maps model IDs to their shared quota group.
 // Models within the same quota group share rate limits - when one model
 // hits quota, all models in the same group are blocked.
@@ -98,9 +107,23 @@ func AntigravityQuotaGroupResolver(provider, model string) string {
 
 // quotaGroupIndex maintains a reverse index from quota group to blocked state.
 // This enables O(1) lookup instead of O(N) iteration over ModelStates.
+//
+// When a QuotaGroupStore is registered via RegisterQuotaGroupStore, this
+// index also acts as an L1 cache in front of it: writes go through to the
+// store so peer replicas converge on the same blocked state, and reads fall
+// back to the store on a local miss so a cold replica doesn't have to
+// re-probe an already-exhausted credential.
 type quotaGroupIndex struct {
+	mu sync.Mutex
+	// provider is the provider this index belongs to, used to key the
+	// shared QuotaGroupStore and to route WatchBlocked events back to it.
+	provider string
 	// blockedGroups maps quota group name to its blocked state
 	blockedGroups map[string]*quotaGroupState
+	// bucketShards holds token/leaky bucket state when the provider has a
+	// QuotaGroupPolicy registered; left nil for the default blocked-until
+	// behavior. Lazily allocated by Take on first use.
+	bucketShards *[bucketShardCount]*bucketShard
 }
 
 type quotaGroupState struct {
@@ -110,29 +133,41 @@ type quotaGroupState struct {
 	NextRecoverAt time.Time
 	// SourceModel is the model that originally triggered the quota block
 	SourceModel string
+	// AuthID is the credential that originally triggered the quota block
+	AuthID string
+	// BlockedAt is when this block started, used to observe
+	// llmmux_quota_group_block_duration_seconds when it is cleared.
+	BlockedAt time.Time
 }
 
+var (
+	quotaGroupRegistryMu sync.Mutex
+	// quotaGroupRegistry tracks every live quotaGroupIndex per provider so
+	// that QuotaGroupEvents from peer replicas can be applied to all of
+	// them, not just the index that happens to be consulted next.
+	quotaGroupRegistry = make(map[string][]*quotaGroupIndex)
+)
+
 // getOrCreateQuotaGroupIndex returns the quota group index from auth.Runtime,
 // creating it if necessary.
-func getOrCreateQuotaGroupIndex(auth *Auth) *quotaGroupIndex {
+func getOrCreateQuotaGroupIndex(auth *Auth, provider string) *quotaGroupIndex {
 	if auth == nil {
 		return nil
 	}
-	if auth.Runtime == nil {
-		idx := &quotaGroupIndex{
-			blockedGroups: make(map[string]*quotaGroupState),
-		}
-		auth.Runtime = idx
-		return idx
-	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
 	if idx, ok := auth.Runtime.(*quotaGroupIndex); ok {
 		return idx
 	}
-	// Runtime is used for something else, create wrapper
+	// Runtime is empty or used for something else, create wrapper.
 	idx := &quotaGroupIndex{
+		provider:      provider,
 		blockedGroups: make(map[string]*quotaGroupState),
 	}
 	auth.Runtime = idx
+	registerQuotaGroupIndex(provider, idx)
+	if journal := currentQuotaGroupJournal(); journal != nil {
+		journal.loadInto(idx, provider)
+	}
 	return idx
 }
 
@@ -145,11 +180,24 @@ func getQuotaGroupIndex(auth *Auth) *quotaGroupIndex {
 	return idx
 }
 
-// setGroupBlocked marks a quota group as blocked.
-func (idx *quotaGroupIndex) setGroupBlocked(group, sourceModel string, nextRetry, nextRecover time.Time) {
+func registerQuotaGroupIndex(provider string, idx *quotaGroupIndex) {
+	if provider == "" {
+		return
+	}
+	quotaGroupRegistryMu.Lock()
+	quotaGroupRegistry[provider] = append(quotaGroupRegistry[provider], idx)
+	quotaGroupRegistryMu.Unlock()
+}
+
+// setGroupBlocked marks a quota group as blocked, writing through to the
+// shared QuotaGroupStore (if any) so peer replicas see the block without
+// needing to fail their own probe first.
+func (idx *quotaGroupIndex) setGroupBlocked(group, sourceModel, authID string, nextRetry, nextRecover time.Time) {
 	if idx == nil || group == "" {
 		return
 	}
+	blockedAt := time.Now()
+	idx.mu.Lock()
 	if idx.blockedGroups == nil {
 		idx.blockedGroups = make(map[string]*quotaGroupState)
 	}
@@ -157,38 +205,205 @@ func (idx *quotaGroupIndex) setGroupBlocked(group, sourceModel string, nextRetry
 		NextRetryAfter: nextRetry,
 		NextRecoverAt:  nextRecover,
 		SourceModel:    sourceModel,
+		AuthID:         authID,
+		BlockedAt:      blockedAt,
 	}
+	provider := idx.provider
+	idx.mu.Unlock()
+
+	metrics.QuotaGroupBlocked.WithLabelValues(provider, group, sourceModel).Set(1)
+	scheduleQuotaGroupJournalWrite()
+
+	store := currentQuotaGroupStore()
+	if store == nil {
+		return
+	}
+	ttl := quotaGroupBlockTTL(nextRetry, nextRecover)
+	if ttl <= 0 {
+		return
+	}
+	state := QuotaGroupBlockState{
+		NextRetryAfter: nextRetry,
+		NextRecoverAt:  nextRecover,
+		SourceModel:    sourceModel,
+		AuthID:         authID,
+		BlockedAt:      blockedAt,
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), quotaGroupStoreTimeout)
+		defer cancel()
+		_ = store.SetBlocked(ctx, provider, group, state, ttl)
+	}()
 }
 
-// clearGroup removes a quota group from blocked state.
+// clearGroup removes a quota group from blocked state, both locally and in
+// the shared QuotaGroupStore (if any).
 func (idx *quotaGroupIndex) clearGroup(group string) {
-	if idx == nil || idx.blockedGroups == nil {
+	if idx == nil || group == "" {
 		return
 	}
+	idx.mu.Lock()
+	state, had := idx.blockedGroups[group]
 	delete(idx.blockedGroups, group)
+	provider := idx.provider
+	idx.mu.Unlock()
+
+	if had && state != nil {
+		metrics.QuotaGroupBlocked.WithLabelValues(provider, group, state.SourceModel).Set(0)
+		if !state.BlockedAt.IsZero() {
+			metrics.QuotaGroupBlockDuration.WithLabelValues(provider, group).Observe(time.Since(state.BlockedAt).Seconds())
+		}
+	}
+	scheduleQuotaGroupJournalWrite()
+
+	store := currentQuotaGroupStore()
+	if store == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), quotaGroupStoreTimeout)
+		defer cancel()
+		_ = store.ClearBlocked(ctx, provider, group)
+	}()
 }
 
 // isGroupBlocked checks if a quota group is blocked.
-// Returns (blocked, nextRetryAfter) - O(1) lookup.
+// Returns (blocked, nextRetryAfter) - O(1) lookup against the local L1
+// cache, falling back to a read-through against the shared QuotaGroupStore
+// (if any) on a miss.
 func (idx *quotaGroupIndex) isGroupBlocked(group string, now time.Time) (bool, time.Time) {
-	if idx == nil || idx.blockedGroups == nil || group == "" {
+	blocked, next := idx.isGroupBlockedUncounted(group, now)
+	if idx != nil && group != "" {
+		outcome := "allowed"
+		if blocked {
+			outcome = "blocked"
+		}
+		metrics.QuotaGroupHits.WithLabelValues(idx.provider, group, outcome).Inc()
+	}
+	return blocked, next
+}
+
+func (idx *quotaGroupIndex) isGroupBlockedUncounted(group string, now time.Time) (bool, time.Time) {
+	if idx == nil || group == "" {
 		return false, time.Time{}
 	}
-	state, ok := idx.blockedGroups[group]
-	if !ok || state == nil {
+
+	idx.mu.Lock()
+	if state, ok := idx.blockedGroups[group]; ok && state != nil {
+		if state.NextRetryAfter.After(now) {
+			next := state.NextRetryAfter
+			if !state.NextRecoverAt.IsZero() && state.NextRecoverAt.After(now) && state.NextRecoverAt.After(next) {
+				next = state.NextRecoverAt
+			}
+			idx.mu.Unlock()
+			return true, next
+		}
+		// Expired, clean up.
+		delete(idx.blockedGroups, group)
+		metrics.QuotaGroupBlocked.WithLabelValues(idx.provider, group, state.SourceModel).Set(0)
+	}
+	provider := idx.provider
+	idx.mu.Unlock()
+
+	store := currentQuotaGroupStore()
+	if store == nil {
 		return false, time.Time{}
 	}
-	// Check if still blocked
-	if state.NextRetryAfter.After(now) {
-		next := state.NextRetryAfter
-		if !state.NextRecoverAt.IsZero() && state.NextRecoverAt.After(now) && state.NextRecoverAt.After(next) {
-			next = state.NextRecoverAt
+	ctx, cancel := context.WithTimeout(context.Background(), quotaGroupStoreTimeout)
+	defer cancel()
+	remote, ok, err := store.GetBlocked(ctx, provider, group)
+	if err != nil || !ok {
+		return false, time.Time{}
+	}
+	next := remote.NextRetryAfter
+	if !remote.NextRecoverAt.IsZero() && remote.NextRecoverAt.After(next) {
+		next = remote.NextRecoverAt
+	}
+	if !next.After(now) {
+		return false, time.Time{}
+	}
+
+	idx.mu.Lock()
+	if idx.blockedGroups == nil {
+		idx.blockedGroups = make(map[string]*quotaGroupState)
+	}
+	blockedAt := remote.BlockedAt
+	if blockedAt.IsZero() {
+		// Remote state predates BlockedAt being round-tripped; approximate
+		// with now rather than reporting a zero block duration.
+		blockedAt = now
+	}
+	idx.blockedGroups[group] = &quotaGroupState{
+		NextRetryAfter: remote.NextRetryAfter,
+		NextRecoverAt:  remote.NextRecoverAt,
+		SourceModel:    remote.SourceModel,
+		AuthID:         remote.AuthID,
+		BlockedAt:      blockedAt,
+	}
+	idx.mu.Unlock()
+	metrics.QuotaGroupBlocked.WithLabelValues(provider, group, remote.SourceModel).Set(1)
+	return true, next
+}
+
+// quotaGroupBlockTTL derives the store TTL from a block's retry window so
+// entries self-clean without an explicit delete.
+func quotaGroupBlockTTL(nextRetry, nextRecover time.Time) time.Duration {
+	deadline := nextRetry
+	if nextRecover.After(deadline) {
+		deadline = nextRecover
+	}
+	if deadline.IsZero() {
+		return 0
+	}
+	return time.Until(deadline)
+}
+
+// applyQuotaGroupEvent updates every local index for evt.Provider in
+// response to a change observed on a peer replica, so this instance evicts
+// promptly instead of waiting for its own probe or TTL expiry.
+func applyQuotaGroupEvent(evt QuotaGroupEvent) {
+	quotaGroupRegistryMu.Lock()
+	indices := append([]*quotaGroupIndex(nil), quotaGroupRegistry[evt.Provider]...)
+	quotaGroupRegistryMu.Unlock()
+
+	for _, idx := range indices {
+		idx.mu.Lock()
+		switch evt.Kind {
+		case QuotaGroupEventCleared:
+			prior, had := idx.blockedGroups[evt.Group]
+			delete(idx.blockedGroups, evt.Group)
+			idx.mu.Unlock()
+
+			if had && prior != nil {
+				metrics.QuotaGroupBlocked.WithLabelValues(evt.Provider, evt.Group, prior.SourceModel).Set(0)
+				if !prior.BlockedAt.IsZero() {
+					metrics.QuotaGroupBlockDuration.WithLabelValues(evt.Provider, evt.Group).Observe(time.Since(prior.BlockedAt).Seconds())
+				}
+			}
+			continue
+		case QuotaGroupEventBlocked:
+			if idx.blockedGroups == nil {
+				idx.blockedGroups = make(map[string]*quotaGroupState)
+			}
+			blockedAt := evt.State.BlockedAt
+			if blockedAt.IsZero() {
+				// Event predates BlockedAt being round-tripped; approximate
+				// with now rather than reporting a zero block duration.
+				blockedAt = time.Now()
+			}
+			idx.blockedGroups[evt.Group] = &quotaGroupState{
+				NextRetryAfter: evt.State.NextRetryAfter,
+				NextRecoverAt:  evt.State.NextRecoverAt,
+				SourceModel:    evt.State.SourceModel,
+				AuthID:         evt.State.AuthID,
+				BlockedAt:      blockedAt,
+			}
+			idx.mu.Unlock()
+			metrics.QuotaGroupBlocked.WithLabelValues(evt.Provider, evt.Group, evt.State.SourceModel).Set(1)
+			continue
 		}
-		return true, next
+		idx.mu.Unlock()
 	}
-	// Expired, clean up
-	delete(idx.blockedGroups, group)
-	return false, time.Time{}
 }
 
 // init registers default quota group resolvers for known providers
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// adminQuotaGroupsPath is the collection route; the trailing-slash form is
+// registered separately so http.ServeMux also matches
+// /admin/quota-groups/{provider}/{group}.
+const adminQuotaGroupsPath = "/admin/quota-groups"
+
+// AdminQuotaGroupsHandler returns the admin HTTP handler for inspecting and
+// manually clearing quota-group blocks without a restart:
+//
+//	GET    /admin/quota-groups                   list every blocked group
+//	DELETE /admin/quota-groups/{provider}/{group} clear one
+func AdminQuotaGroupsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(adminQuotaGroupsPath, handleListQuotaGroups)
+	mux.HandleFunc(adminQuotaGroupsPath+"/", handleClearQuotaGroup)
+	return mux
+}
+
+func handleListQuotaGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(snapshotQuotaGroups())
+}
+
+func handleClearQuotaGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	provider, group, ok := parseAdminQuotaGroupPath(r.URL.Path)
+	if !ok {
+		http.Error(w, "expected /admin/quota-groups/{provider}/{group}", http.StatusBadRequest)
+		return
+	}
+	clearQuotaGroupByProvider(provider, group)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseAdminQuotaGroupPath(path string) (provider, group string, ok bool) {
+	rest := strings.TrimPrefix(path, adminQuotaGroupsPath+"/")
+	if rest == path {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.Trim(rest, "/"), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// clearQuotaGroupByProvider clears group on every local index for provider,
+// going through clearGroup so the QuotaGroupStore write-through and journal
+// debounce behave the same as a normal clear. It also clears the registered
+// QuotaGroupStore directly: a replica that has never lazily created a
+// quotaGroupIndex for provider (e.g. freshly started, or one that hasn't
+// served that provider yet) has no index to drive clearGroup, and without
+// this the distributed block would survive an operator's DELETE even though
+// the handler reports success.
+func clearQuotaGroupByProvider(provider, group string) {
+	quotaGroupRegistryMu.Lock()
+	indices := append([]*quotaGroupIndex(nil), quotaGroupRegistry[provider]...)
+	quotaGroupRegistryMu.Unlock()
+	for _, idx := range indices {
+		idx.clearGroup(group)
+	}
+
+	if store := currentQuotaGroupStore(); store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), quotaGroupStoreTimeout)
+		defer cancel()
+		_ = store.ClearBlocked(ctx, provider, group)
+	}
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// quotaGroupJournalDebounce is how long QuotaGroupJournal waits after a
+// block/clear before writing, coalescing bursts of changes into a single
+// syscall instead of hitting disk on every hot-path call.
+const quotaGroupJournalDebounce = time.Second
+
+// QuotaGroupJournal persists quota-group blocked state to disk so a
+// restart doesn't forget active blocks and immediately re-probe credentials
+// that were already known to be exhausted. Writes are async and debounced;
+// the file is read each time getOrCreateQuotaGroupIndex creates a new index,
+// restoring that provider's unexpired blocks into it.
+type QuotaGroupJournal struct {
+	path string
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// quotaGroupJournalEntry is the on-disk representation of one blocked
+// quota group.
+type quotaGroupJournalEntry struct {
+	Provider       string    `json:"provider"`
+	Group          string    `json:"group"`
+	NextRetryAfter time.Time `json:"next_retry_after"`
+	NextRecoverAt  time.Time `json:"next_recover_at"`
+	SourceModel    string    `json:"source_model"`
+	AuthID         string    `json:"auth_id"`
+	// BlockedAt is when the block actually started, round-tripped so a
+	// restart doesn't reset llmmux_quota_group_block_duration_seconds to
+	// only count time since the reload.
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// NewQuotaGroupJournal creates a journal that snapshots to path as JSON.
+func NewQuotaGroupJournal(path string) *QuotaGroupJournal {
+	return &QuotaGroupJournal{path: path}
+}
+
+var (
+	quotaGroupJournalMu sync.RWMutex
+	quotaGroupJournal   *QuotaGroupJournal
+)
+
+// RegisterQuotaGroupJournal installs the journal used to persist and
+// restore quota-group blocked state across restarts. Passing nil disables
+// persistence.
+func RegisterQuotaGroupJournal(journal *QuotaGroupJournal) {
+	quotaGroupJournalMu.Lock()
+	quotaGroupJournal = journal
+	quotaGroupJournalMu.Unlock()
+}
+
+func currentQuotaGroupJournal() *QuotaGroupJournal {
+	quotaGroupJournalMu.RLock()
+	defer quotaGroupJournalMu.RUnlock()
+	return quotaGroupJournal
+}
+
+// scheduleQuotaGroupJournalWrite debounces a snapshot write on the
+// registered journal, if any.
+func scheduleQuotaGroupJournalWrite() {
+	if journal := currentQuotaGroupJournal(); journal != nil {
+		journal.scheduleWrite()
+	}
+}
+
+// scheduleWrite coalesces writes: if one is already pending, this is a
+// no-op, so a burst of blocks/clears within the debounce window produces a
+// single snapshot instead of a syscall per call.
+func (j *QuotaGroupJournal) scheduleWrite() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.timer != nil {
+		return
+	}
+	j.timer = time.AfterFunc(quotaGroupJournalDebounce, func() {
+		j.mu.Lock()
+		j.timer = nil
+		j.mu.Unlock()
+		j.writeSnapshot()
+	})
+}
+
+// writeSnapshot persists the current union of every index's blocked groups.
+// Writes go to a temp file and are renamed into place so a crash mid-write
+// can't leave a truncated journal.
+func (j *QuotaGroupJournal) writeSnapshot() {
+	entries := snapshotQuotaGroups()
+	payload, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, payload, 0o600); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, j.path)
+}
+
+// loadInto restores provider's unexpired blocked groups from disk into idx.
+// Entries whose retry/recovery deadline has already passed are dropped
+// rather than reapplied.
+func (j *QuotaGroupJournal) loadInto(idx *quotaGroupIndex, provider string) {
+	entries, err := j.readEntries()
+	if err != nil || len(entries) == 0 {
+		return
+	}
+	now := time.Now()
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, e := range entries {
+		if e.Provider != provider {
+			continue
+		}
+		deadline := e.NextRetryAfter
+		if e.NextRecoverAt.After(deadline) {
+			deadline = e.NextRecoverAt
+		}
+		if !deadline.After(now) {
+			continue
+		}
+		if idx.blockedGroups == nil {
+			idx.blockedGroups = make(map[string]*quotaGroupState)
+		}
+		blockedAt := e.BlockedAt
+		if blockedAt.IsZero() {
+			// Older journal entries predating this field; approximate with
+			// now rather than leaving it zero (which would read as an
+			// instantaneous block duration).
+			blockedAt = now
+		}
+		idx.blockedGroups[e.Group] = &quotaGroupState{
+			NextRetryAfter: e.NextRetryAfter,
+			NextRecoverAt:  e.NextRecoverAt,
+			SourceModel:    e.SourceModel,
+			AuthID:         e.AuthID,
+			BlockedAt:      blockedAt,
+		}
+	}
+}
+
+func (j *QuotaGroupJournal) readEntries() ([]quotaGroupJournalEntry, error) {
+	data, err := os.ReadFile(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []quotaGroupJournalEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// snapshotQuotaGroups gathers every blocked group across every registered
+// index, deduplicating by provider/group since multiple Auth instances of
+// the same provider share the registry.
+func snapshotQuotaGroups() []quotaGroupJournalEntry {
+	quotaGroupRegistryMu.Lock()
+	byProvider := make(map[string][]*quotaGroupIndex, len(quotaGroupRegistry))
+	for provider, idxs := range quotaGroupRegistry {
+		byProvider[provider] = append([]*quotaGroupIndex(nil), idxs...)
+	}
+	quotaGroupRegistryMu.Unlock()
+
+	seen := make(map[string]struct{})
+	var entries []quotaGroupJournalEntry
+	for provider, idxs := range byProvider {
+		for _, idx := range idxs {
+			idx.mu.Lock()
+			for group, state := range idx.blockedGroups {
+				key := provider + "|" + group
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				entries = append(entries, quotaGroupJournalEntry{
+					Provider:       provider,
+					Group:          group,
+					NextRetryAfter: state.NextRetryAfter,
+					NextRecoverAt:  state.NextRecoverAt,
+					SourceModel:    state.SourceModel,
+					AuthID:         state.AuthID,
+					BlockedAt:      state.BlockedAt,
+				})
+			}
+			idx.mu.Unlock()
+		}
+	}
+	return entries
+}
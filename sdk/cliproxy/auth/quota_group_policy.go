@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LongLenart/llm-mux/internal/metrics"
+)
+
+// QuotaGroupAlgorithm selects how a quota group enforces its budget.
+type QuotaGroupAlgorithm int
+
+const (
+	// AlgorithmBlockedUntil is the original behavior: a group is either
+	// blocked until NextRetryAfter/NextRecoverAt or fully open. This is the
+	// default for providers whose error responses only report a retry time
+	// rather than an explicit rate limit.
+	AlgorithmBlockedUntil QuotaGroupAlgorithm = iota
+	// AlgorithmTokenBucket enforces a capacity/refillRate budget: tokens
+	// refill lazily over time and each request subtracts n.
+	AlgorithmTokenBucket
+	// AlgorithmLeakyBucket enforces a capacity/leakRate budget: a counter
+	// drains linearly over time and each request adds to it.
+	AlgorithmLeakyBucket
+)
+
+// QuotaGroupPolicy configures the rate-limiting algorithm applied to every
+// quota group under a provider.
+type QuotaGroupPolicy struct {
+	Algorithm QuotaGroupAlgorithm
+	// Capacity is the bucket size, in the same unit as Take's n.
+	Capacity float64
+	// RefillRate is tokens/sec, used by AlgorithmTokenBucket.
+	RefillRate float64
+	// LeakRate is units/sec, used by AlgorithmLeakyBucket.
+	LeakRate float64
+}
+
+var (
+	quotaGroupPolicyMu sync.RWMutex
+	quotaGroupPolicies = make(map[string]QuotaGroupPolicy)
+)
+
+// RegisterQuotaGroupPolicy configures the rate-limiting algorithm used for a
+// provider's quota groups. Providers without a registered policy keep the
+// original blocked-until-retry behavior. For example, Antigravity's Claude
+// family can be configured as a 60-RPM token bucket instead of a hard block:
+//
+//	auth.RegisterQuotaGroupPolicy("antigravity", auth.QuotaGroupPolicy{
+//		Algorithm:  auth.AlgorithmTokenBucket,
+//		Capacity:   60,
+//		RefillRate: 1, // 60 tokens/min
+//	})
+func RegisterQuotaGroupPolicy(provider string, policy QuotaGroupPolicy) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return
+	}
+	quotaGroupPolicyMu.Lock()
+	quotaGroupPolicies[provider] = policy
+	quotaGroupPolicyMu.Unlock()
+}
+
+func quotaGroupPolicyFor(provider string) (QuotaGroupPolicy, bool) {
+	quotaGroupPolicyMu.RLock()
+	defer quotaGroupPolicyMu.RUnlock()
+	policy, ok := quotaGroupPolicies[provider]
+	return policy, ok
+}
+
+const bucketShardCount = 16
+
+// bucketShard holds a fraction of a provider's token/leaky buckets behind
+// its own lock, mirroring util.TokenCache's sharding so hot groups don't
+// serialize on a single mutex.
+type bucketShard struct {
+	mu      sync.Mutex
+	buckets map[string]*quotaBucket
+}
+
+// quotaBucket is the mutable state for either bucket algorithm; only the
+// fields relevant to the configured algorithm are kept up to date.
+type quotaBucket struct {
+	tokens     float64
+	lastRefill time.Time
+	level      float64
+	lastLeak   time.Time
+}
+
+func newBucketShards() *[bucketShardCount]*bucketShard {
+	var shards [bucketShardCount]*bucketShard
+	for i := range shards {
+		shards[i] = &bucketShard{buckets: make(map[string]*quotaBucket)}
+	}
+	return &shards
+}
+
+func bucketShardFor(shards *[bucketShardCount]*bucketShard, group string) *bucketShard {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(group))
+	return shards[h.Sum64()%bucketShardCount]
+}
+
+// Take attempts to consume n units of the provider's quota-group budget
+// under its configured QuotaGroupPolicy. If no policy is registered for the
+// provider, it falls back to the original isGroupBlocked check. ok reports
+// whether the request is allowed; when ok is false, retryAfter is the
+// caller's suggested backoff.
+func (idx *quotaGroupIndex) Take(group string, n int, now time.Time) (ok bool, retryAfter time.Duration) {
+	if idx == nil || group == "" {
+		return true, 0
+	}
+
+	policy, hasPolicy := quotaGroupPolicyFor(idx.provider)
+	if !hasPolicy || policy.Algorithm == AlgorithmBlockedUntil {
+		if blocked, next := idx.isGroupBlocked(group, now); blocked {
+			return false, next.Sub(now)
+		}
+		return true, 0
+	}
+
+	idx.mu.Lock()
+	if idx.bucketShards == nil {
+		idx.bucketShards = newBucketShards()
+	}
+	shards := idx.bucketShards
+	idx.mu.Unlock()
+
+	shard := bucketShardFor(shards, group)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	bucket := shard.buckets[group]
+	if bucket == nil {
+		bucket = &quotaBucket{tokens: policy.Capacity, lastRefill: now, lastLeak: now}
+		shard.buckets[group] = bucket
+	}
+
+	switch policy.Algorithm {
+	case AlgorithmTokenBucket:
+		ok, retryAfter = takeTokenBucket(bucket, policy, n, now)
+	case AlgorithmLeakyBucket:
+		ok, retryAfter = takeLeakyBucket(bucket, policy, n, now)
+	default:
+		ok, retryAfter = true, 0
+	}
+
+	outcome := "allowed"
+	if !ok {
+		outcome = "blocked"
+	}
+	metrics.QuotaGroupHits.WithLabelValues(idx.provider, group, outcome).Inc()
+	return ok, retryAfter
+}
+
+// takeTokenBucket refills lazily as tokens = min(capacity, tokens +
+// elapsed*refillRate), then checks whether n tokens are available before
+// committing the subtraction. A rejected request leaves tokens untouched, so
+// a caller that keeps retrying a blocked group doesn't dig the bucket
+// further into debt and inflate its own retryAfter.
+func takeTokenBucket(bucket *quotaBucket, policy QuotaGroupPolicy, n int, now time.Time) (bool, time.Duration) {
+	if elapsed := now.Sub(bucket.lastRefill).Seconds(); elapsed > 0 {
+		bucket.tokens = minFloat(policy.Capacity, bucket.tokens+elapsed*policy.RefillRate)
+		bucket.lastRefill = now
+	}
+	deficit := float64(n) - bucket.tokens
+	if deficit > 0 {
+		if policy.RefillRate <= 0 {
+			return false, 0
+		}
+		retryAfter := time.Duration(deficit / policy.RefillRate * float64(time.Second))
+		return false, retryAfter
+	}
+	bucket.tokens -= float64(n)
+	return true, 0
+}
+
+// takeLeakyBucket drains the counter linearly as level = max(0, level -
+// elapsed*leakRate), then rejects if adding n would exceed capacity.
+func takeLeakyBucket(bucket *quotaBucket, policy QuotaGroupPolicy, n int, now time.Time) (bool, time.Duration) {
+	if elapsed := now.Sub(bucket.lastLeak).Seconds(); elapsed > 0 {
+		bucket.level = maxFloat(0, bucket.level-elapsed*policy.LeakRate)
+		bucket.lastLeak = now
+	}
+	if bucket.level+float64(n) > policy.Capacity {
+		if policy.LeakRate <= 0 {
+			return false, 0
+		}
+		over := bucket.level + float64(n) - policy.Capacity
+		retryAfter := time.Duration(over / policy.LeakRate * float64(time.Second))
+		return false, retryAfter
+	}
+	bucket.level += float64(n)
+	return true, 0
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
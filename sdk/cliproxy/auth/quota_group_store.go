@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// QuotaGroupBlockState is the serializable blocked state for a quota group,
+// shared between replicas through a QuotaGroupStore.
+type QuotaGroupBlockState struct {
+	// NextRetryAfter is the earliest time any model in this group can retry.
+	NextRetryAfter time.Time
+	// NextRecoverAt is when quota recovers.
+	NextRecoverAt time.Time
+	// SourceModel is the model that originally triggered the quota block.
+	SourceModel string
+	// AuthID is the credential that originally triggered the quota block.
+	AuthID string
+	// BlockedAt is when the block actually started on the replica that
+	// called setGroupBlocked, round-tripped so peers that read or watch
+	// this state observe llmmux_quota_group_block_duration_seconds against
+	// the true block start instead of their own discovery time.
+	BlockedAt time.Time
+}
+
+// QuotaGroupEventKind identifies what changed in a QuotaGroupEvent.
+type QuotaGroupEventKind int
+
+const (
+	// QuotaGroupEventBlocked means a peer blocked provider/group.
+	QuotaGroupEventBlocked QuotaGroupEventKind = iota
+	// QuotaGroupEventCleared means a peer cleared provider/group.
+	QuotaGroupEventCleared
+)
+
+// QuotaGroupEvent is emitted by a QuotaGroupStore whenever a peer replica
+// blocks or clears a quota group, so local L1 caches can evict promptly
+// instead of waiting for their own probe to fail or the TTL to expire.
+type QuotaGroupEvent struct {
+	Provider string
+	Group    string
+	Kind     QuotaGroupEventKind
+	State    QuotaGroupBlockState
+}
+
+// QuotaGroupStore is a pluggable backend for sharing quota-group blocked
+// state across llm-mux replicas behind a load balancer, so that one
+// instance's quota exhaustion is visible to its peers instead of each
+// replica re-learning it independently by burning through the same
+// credential. setGroupBlocked/isGroupBlocked/clearGroup write-through and
+// read-through a registered store while keeping the per-Auth
+// quotaGroupIndex as an L1 cache for O(1) hot-path lookups.
+type QuotaGroupStore interface {
+	// SetBlocked persists the blocked state for provider/group with a TTL
+	// equal to the remaining retry window, so expired blocks self-clean
+	// without requiring an explicit delete.
+	SetBlocked(ctx context.Context, provider, group string, state QuotaGroupBlockState, ttl time.Duration) error
+	// GetBlocked returns the blocked state for provider/group, if present.
+	GetBlocked(ctx context.Context, provider, group string) (QuotaGroupBlockState, bool, error)
+	// ClearBlocked removes the blocked state for provider/group.
+	ClearBlocked(ctx context.Context, provider, group string) error
+	// WatchBlocked streams blocked/cleared events for every provider/group
+	// as they happen on any replica. The returned channel is closed when
+	// ctx is done or the store can no longer watch.
+	WatchBlocked(ctx context.Context) (<-chan QuotaGroupEvent, error)
+}
+
+var (
+	quotaGroupStoreMu     sync.RWMutex
+	quotaGroupStore       QuotaGroupStore
+	quotaGroupWatchCancel context.CancelFunc
+)
+
+// RegisterQuotaGroupStore installs a shared QuotaGroupStore used to
+// write-through/read-through quota-group blocked state across replicas.
+// Passing nil restores the in-memory-only behavior. Registering a store
+// starts a background watch that fans its change events out to every
+// local quotaGroupIndex; replacing or clearing the store cancels the
+// previous watch so its underlying subscription doesn't leak.
+func RegisterQuotaGroupStore(store QuotaGroupStore) {
+	quotaGroupStoreMu.Lock()
+	if quotaGroupWatchCancel != nil {
+		quotaGroupWatchCancel()
+		quotaGroupWatchCancel = nil
+	}
+	quotaGroupStore = store
+	var ctx context.Context
+	if store != nil {
+		ctx, quotaGroupWatchCancel = context.WithCancel(context.Background())
+	}
+	quotaGroupStoreMu.Unlock()
+
+	if store != nil {
+		go watchQuotaGroupStore(ctx, store)
+	}
+}
+
+func currentQuotaGroupStore() QuotaGroupStore {
+	quotaGroupStoreMu.RLock()
+	defer quotaGroupStoreMu.RUnlock()
+	return quotaGroupStore
+}
+
+// watchQuotaGroupStore subscribes to store change events until ctx is
+// canceled by a subsequent RegisterQuotaGroupStore call, and applies each
+// event to the local indices.
+func watchQuotaGroupStore(ctx context.Context, store QuotaGroupStore) {
+	ch, err := store.WatchBlocked(ctx)
+	if err != nil || ch == nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-ch:
+			if !ok {
+				return
+			}
+			applyQuotaGroupEvent(evt)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package quotastore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/LongLenart/llm-mux/sdk/cliproxy/auth"
+)
+
+// etcdPrefix namespaces quota-group keys in the etcd keyspace.
+const etcdPrefix = "/llmmux/quota-group/"
+
+// EtcdStore is an auth.QuotaGroupStore backed by etcd. Blocked state is
+// stored as a JSON value attached to a lease scoped to the block's TTL, so
+// expired blocks self-clean, and watch events on the key prefix drive
+// WatchBlocked so peers evict their L1 cache promptly.
+type EtcdStore struct {
+	client *clientv3.Client
+}
+
+// NewEtcdStore wraps an existing etcd client as a QuotaGroupStore.
+func NewEtcdStore(client *clientv3.Client) *EtcdStore {
+	return &EtcdStore{client: client}
+}
+
+func etcdKey(provider, group string) string {
+	return etcdPrefix + provider + "/" + group
+}
+
+// SetBlocked implements auth.QuotaGroupStore.
+func (s *EtcdStore) SetBlocked(ctx context.Context, provider, group string, state auth.QuotaGroupBlockState, ttl time.Duration) error {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("quotastore: marshal blocked state: %w", err)
+	}
+
+	// Round up to the nearest second: etcd leases are second-granular, and
+	// truncating would expire the lease before the NextRetryAfter it's
+	// meant to mirror, letting peers re-probe an exhausted credential
+	// early. A sub-second ttl still gets a 1-second lease rather than the
+	// 0 (effectively no TTL) that truncation could otherwise produce.
+	seconds := int64(math.Ceil(ttl.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	lease, err := s.client.Grant(ctx, seconds)
+	if err != nil {
+		return fmt.Errorf("quotastore: grant lease: %w", err)
+	}
+	if _, err := s.client.Put(ctx, etcdKey(provider, group), string(payload), clientv3.WithLease(lease.ID)); err != nil {
+		return fmt.Errorf("quotastore: put blocked state: %w", err)
+	}
+	return nil
+}
+
+// GetBlocked implements auth.QuotaGroupStore.
+func (s *EtcdStore) GetBlocked(ctx context.Context, provider, group string) (auth.QuotaGroupBlockState, bool, error) {
+	resp, err := s.client.Get(ctx, etcdKey(provider, group))
+	if err != nil {
+		return auth.QuotaGroupBlockState{}, false, fmt.Errorf("quotastore: get blocked state: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return auth.QuotaGroupBlockState{}, false, nil
+	}
+	var state auth.QuotaGroupBlockState
+	if err := json.Unmarshal(resp.Kvs[0].Value, &state); err != nil {
+		return auth.QuotaGroupBlockState{}, false, fmt.Errorf("quotastore: unmarshal blocked state: %w", err)
+	}
+	return state, true, nil
+}
+
+// ClearBlocked implements auth.QuotaGroupStore.
+func (s *EtcdStore) ClearBlocked(ctx context.Context, provider, group string) error {
+	if _, err := s.client.Delete(ctx, etcdKey(provider, group)); err != nil {
+		return fmt.Errorf("quotastore: delete blocked state: %w", err)
+	}
+	return nil
+}
+
+// WatchBlocked implements auth.QuotaGroupStore.
+func (s *EtcdStore) WatchBlocked(ctx context.Context) (<-chan auth.QuotaGroupEvent, error) {
+	out := make(chan auth.QuotaGroupEvent)
+	watchCh := s.client.Watch(ctx, etcdPrefix, clientv3.WithPrefix())
+
+	go func() {
+		defer close(out)
+		for resp := range watchCh {
+			for _, ev := range resp.Events {
+				evt, ok := decodeEtcdEvent(ev)
+				if !ok {
+					continue
+				}
+				select {
+				case out <- evt:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func decodeEtcdEvent(ev *clientv3.Event) (auth.QuotaGroupEvent, bool) {
+	provider, group, ok := splitEtcdKey(string(ev.Kv.Key))
+	if !ok {
+		return auth.QuotaGroupEvent{}, false
+	}
+	if ev.Type == clientv3.EventTypeDelete {
+		return auth.QuotaGroupEvent{Provider: provider, Group: group, Kind: auth.QuotaGroupEventCleared}, true
+	}
+	var state auth.QuotaGroupBlockState
+	if err := json.Unmarshal(ev.Kv.Value, &state); err != nil {
+		return auth.QuotaGroupEvent{}, false
+	}
+	return auth.QuotaGroupEvent{Provider: provider, Group: group, Kind: auth.QuotaGroupEventBlocked, State: state}, true
+}
+
+// splitEtcdKey recovers provider/group from a key of the form
+// "/llmmux/quota-group/<provider>/<group>".
+func splitEtcdKey(key string) (provider, group string, ok bool) {
+	trimmed := key[len(etcdPrefix):]
+	for i := 0; i < len(trimmed); i++ {
+		if trimmed[i] == '/' {
+			return trimmed[:i], trimmed[i+1:], true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,135 @@
+// Package quotastore provides QuotaGroupStore backends so quota-group
+// blocked state can be shared across llm-mux replicas instead of being
+// re-learned independently by each one.
+package quotastore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/LongLenart/llm-mux/sdk/cliproxy/auth"
+)
+
+// keyPrefix namespaces quota-group keys so the store can share a Redis
+// keyspace with other llm-mux data without colliding.
+const keyPrefix = "llmmux:quota-group:"
+
+// channelName is the Redis pub/sub channel used to fan out blocked/cleared
+// events to every replica watching the store.
+const channelName = "llmmux:quota-group:events"
+
+// RedisStore is an auth.QuotaGroupStore backed by Redis. Blocked state is
+// stored as a JSON value with a TTL so expired blocks self-clean, and
+// changes are published on a pub/sub channel so peers evict their L1 cache
+// without waiting for their own probe to fail.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore wraps an existing Redis client as a QuotaGroupStore.
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+type redisEntry struct {
+	Provider string                    `json:"provider"`
+	Group    string                    `json:"group"`
+	State    auth.QuotaGroupBlockState `json:"state"`
+}
+
+func redisKey(provider, group string) string {
+	return keyPrefix + provider + ":" + group
+}
+
+// SetBlocked implements auth.QuotaGroupStore.
+func (s *RedisStore) SetBlocked(ctx context.Context, provider, group string, state auth.QuotaGroupBlockState, ttl time.Duration) error {
+	entry := redisEntry{Provider: provider, Group: group, State: state}
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("quotastore: marshal blocked state: %w", err)
+	}
+	if err := s.client.Set(ctx, redisKey(provider, group), payload, ttl).Err(); err != nil {
+		return fmt.Errorf("quotastore: set blocked state: %w", err)
+	}
+	return s.publish(ctx, auth.QuotaGroupEvent{
+		Provider: provider,
+		Group:    group,
+		Kind:     auth.QuotaGroupEventBlocked,
+		State:    state,
+	})
+}
+
+// GetBlocked implements auth.QuotaGroupStore.
+func (s *RedisStore) GetBlocked(ctx context.Context, provider, group string) (auth.QuotaGroupBlockState, bool, error) {
+	payload, err := s.client.Get(ctx, redisKey(provider, group)).Bytes()
+	if err == redis.Nil {
+		return auth.QuotaGroupBlockState{}, false, nil
+	}
+	if err != nil {
+		return auth.QuotaGroupBlockState{}, false, fmt.Errorf("quotastore: get blocked state: %w", err)
+	}
+	var entry redisEntry
+	if err := json.Unmarshal(payload, &entry); err != nil {
+		return auth.QuotaGroupBlockState{}, false, fmt.Errorf("quotastore: unmarshal blocked state: %w", err)
+	}
+	return entry.State, true, nil
+}
+
+// ClearBlocked implements auth.QuotaGroupStore.
+func (s *RedisStore) ClearBlocked(ctx context.Context, provider, group string) error {
+	if err := s.client.Del(ctx, redisKey(provider, group)).Err(); err != nil {
+		return fmt.Errorf("quotastore: clear blocked state: %w", err)
+	}
+	return s.publish(ctx, auth.QuotaGroupEvent{
+		Provider: provider,
+		Group:    group,
+		Kind:     auth.QuotaGroupEventCleared,
+	})
+}
+
+// WatchBlocked implements auth.QuotaGroupStore.
+func (s *RedisStore) WatchBlocked(ctx context.Context) (<-chan auth.QuotaGroupEvent, error) {
+	sub := s.client.Subscribe(ctx, channelName)
+	msgs := sub.Channel()
+	out := make(chan auth.QuotaGroupEvent)
+
+	go func() {
+		defer close(out)
+		defer sub.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				var evt auth.QuotaGroupEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &evt); err != nil {
+					continue
+				}
+				select {
+				case out <- evt:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (s *RedisStore) publish(ctx context.Context, evt auth.QuotaGroupEvent) error {
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("quotastore: marshal event: %w", err)
+	}
+	if err := s.client.Publish(ctx, channelName, payload).Err(); err != nil {
+		return fmt.Errorf("quotastore: publish event: %w", err)
+	}
+	return nil
+}